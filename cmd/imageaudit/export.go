@@ -0,0 +1,133 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+
+	"k8s.io/contrib/imageaudit/pkg/imageaudit"
+)
+
+// newExportCommand returns the `imageaudit export` command, which pulls
+// one or more images and writes their rootfs (and optionally a Pod
+// manifest) to disk.
+func newExportCommand() *cobra.Command {
+	var (
+		image, fromCluster, project, kubeconfig, source string
+		rootfsDir, platform, authFile, emitManifest     string
+		shortNameMode, registriesConf                   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export an image's rootfs without a Docker daemon",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (image == "") == (fromCluster == "") {
+				return fmt.Errorf("exactly one of --image or --from-cluster must be set")
+			}
+
+			plat, err := imageaudit.ParsePlatform(platform)
+			if err != nil {
+				return fmt.Errorf("invalid --platform %q: %v", platform, err)
+			}
+			keychain, err := imageaudit.ResolveKeychain(authFile)
+			if err != nil {
+				return fmt.Errorf("unable to set up registry authentication: %v", err)
+			}
+			resolver, err := imageaudit.NewReferenceResolver(imageaudit.ShortNameMode(shortNameMode), registriesConf)
+			if err != nil {
+				return err
+			}
+			extractor := imageaudit.NewExtractor(keychain, plat, resolver)
+
+			images := []string{image}
+			if fromCluster != "" {
+				lister, err := newLister(source, project, kubeconfig)
+				if err != nil {
+					return err
+				}
+				images, err = lister.List(context.Background(), fromCluster)
+				if err != nil {
+					return fmt.Errorf("failed to list images for cluster %q: %v", fromCluster, err)
+				}
+			}
+
+			for _, imageRef := range images {
+				dir := rootfsDir
+				if fromCluster != "" {
+					dir = filepath.Join(rootfsDir, sanitizeImageRef(imageRef))
+				}
+				if err := exportOne(extractor, imageRef, dir, emitManifest); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&image, "image", "", "Image to export")
+	cmd.Flags().StringVar(&fromCluster, "from-cluster", "", "Export every image the lister found on this cluster, instead of a single --image")
+	cmd.Flags().StringVar(&project, "project", "", "GCP project ID (required when --from-cluster is set and --source=stackdriver)")
+	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig file (used when --from-cluster is set and --source=events)")
+	cmd.Flags().StringVar(&source, "source", "stackdriver", `Where --from-cluster reads pulled-image events from: "stackdriver" or "events"`)
+	cmd.Flags().StringVar(&rootfsDir, "rootfs-dir", "/tmp/rootfs", "Path to store the rootfs (a subdirectory per image when --from-cluster is set)")
+	cmd.Flags().StringVar(&platform, "platform", "", "Platform to pull if the image is a manifest list or OCI index, e.g. linux/amd64 or linux/arm64/v8 (defaults to the host platform)")
+	cmd.Flags().StringVar(&authFile, "auth-file", "", "Path to a Docker config.json to use for registry authentication (consulted before ~/.docker/config.json)")
+	cmd.Flags().StringVar(&emitManifest, "emit-manifest", "", `If set to "yaml" or "json", also write a Pod manifest describing how to run the image next to --rootfs-dir`)
+	cmd.Flags().StringVar(&shortNameMode, "short-name-mode", string(imageaudit.ShortNameModePermissive), `How to resolve images with no registry component: "permissive", "enforcing" or "disabled"`)
+	cmd.Flags().StringVar(&registriesConf, "registries-conf", "", "Path to a registries.conf-style file providing the unqualified-search-registries list used to expand short names")
+	return cmd
+}
+
+// exportOne pulls imageRef, extracts it to rootfsDir, and optionally
+// writes a Pod manifest alongside it.
+func exportOne(extractor *imageaudit.Extractor, imageRef, rootfsDir, emitManifest string) error {
+	glog.Infof("Starting to pull image %q", imageRef)
+	img, resolvedRef, err := extractor.Pull(imageRef)
+	if err != nil {
+		return fmt.Errorf("failed to pull image %q: %v", imageRef, err)
+	}
+	glog.Infof("Successfully pulled image %q", resolvedRef)
+
+	if err := extractor.Export(img, rootfsDir); err != nil {
+		return fmt.Errorf("unable to export image %q: %v", resolvedRef, err)
+	}
+	glog.Infof("Successfully exported image %q to %q", resolvedRef, rootfsDir)
+
+	if emitManifest != "" {
+		if err := extractor.PodManifest(resolvedRef, img, rootfsDir, emitManifest); err != nil {
+			return fmt.Errorf("unable to write pod manifest for %q: %v", resolvedRef, err)
+		}
+		glog.Infof("Wrote pod manifest to %q", imageaudit.ManifestPath(rootfsDir, emitManifest))
+	}
+	return nil
+}
+
+var nonAlnum = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// sanitizeImageRef turns an image reference into a filesystem-safe
+// directory name for `export --from-cluster`, which exports many images
+// under one --rootfs-dir.
+func sanitizeImageRef(imageRef string) string {
+	return nonAlnum.ReplaceAllString(imageRef, "_")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"k8s.io/contrib/imageaudit/pkg/imageaudit"
+)
+
+// newListCommand returns the `imageaudit list` command, which prints
+// every image a cluster has pulled.
+func newListCommand() *cobra.Command {
+	var cluster, project, kubeconfig, source string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List images pulled onto a cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lister, err := newLister(source, project, kubeconfig)
+			if err != nil {
+				return err
+			}
+			images, err := lister.List(context.Background(), cluster)
+			if err != nil {
+				return fmt.Errorf("failed to list images for cluster %q: %v", cluster, err)
+			}
+			for _, image := range images {
+				fmt.Println(image)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&cluster, "cluster", "", "Name of the cluster to inspect")
+	cmd.Flags().StringVar(&project, "project", "", "GCP project ID (required when --source=stackdriver)")
+	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig file (used when --source=events; defaults to in-cluster config)")
+	cmd.Flags().StringVar(&source, "source", "stackdriver", `Where to read pulled-image events from: "stackdriver" or "events"`)
+	cmd.MarkFlagRequired("cluster")
+	return cmd
+}
+
+// newLister builds the Lister backing `list` and `export --from-cluster`
+// for the requested source.
+func newLister(source, project, kubeconfig string) (*imageaudit.Lister, error) {
+	switch source {
+	case "stackdriver":
+		if project == "" {
+			return nil, fmt.Errorf("--project is required when --source=stackdriver")
+		}
+		sdSource, err := imageaudit.NewStackdriverSource(context.Background(), project)
+		if err != nil {
+			return nil, err
+		}
+		return imageaudit.NewLister(sdSource), nil
+	case "events":
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build Kubernetes client config: %v", err)
+		}
+		client, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build Kubernetes client: %v", err)
+		}
+		return imageaudit.NewLister(imageaudit.NewEventSource(client)), nil
+	default:
+		return nil, fmt.Errorf("invalid --source %q; expect %q or %q", source, "stackdriver", "events")
+	}
+}
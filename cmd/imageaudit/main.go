@@ -0,0 +1,37 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command imageaudit lists the images a cluster has pulled and exports
+// image filesystems to a rootfs directory without a Docker daemon.
+package main
+
+import (
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "imageaudit",
+		Short: "List pulled cluster images and export image rootfses",
+	}
+	root.AddCommand(newListCommand())
+	root.AddCommand(newExportCommand())
+
+	if err := root.Execute(); err != nil {
+		glog.Exit(err)
+	}
+}
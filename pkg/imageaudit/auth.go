@@ -0,0 +1,198 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ecrlogin "github.com/awslabs/amazon-ecr-credential-helper/ecr-login"
+	"github.com/chrismellard/docker-credential-acr-env/pkg/credhelper"
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/google"
+)
+
+// Authenticator supplies the basic-auth materials for a single registry
+// pull. This is exactly authn.Authenticator; we name it locally so the
+// Keychain interface below reads standalone.
+type Authenticator = authn.Authenticator
+
+// Keychain resolves credentials for pulling from a registry host (e.g.
+// "gcr.io" or "123456789.dkr.ecr.us-east-1.amazonaws.com"). It mirrors
+// authn.Keychain but takes the registry as a plain string so callers
+// don't need to construct a name.Resource just to look up credentials.
+type Keychain interface {
+	Resolve(registry string) (Authenticator, error)
+}
+
+// helperKeychain adapts a docker-credential-helpers Helper (the
+// interface GCR/ECR/ACR credential binaries implement) into a Keychain,
+// scoped to registries matched by matches.
+type helperKeychain struct {
+	matches func(registry string) bool
+	helper  credentials.Helper
+}
+
+func (k *helperKeychain) Resolve(registry string) (Authenticator, error) {
+	if !k.matches(registry) {
+		return authn.Anonymous, nil
+	}
+	user, secret, err := k.helper.Get(registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve credentials for %q: %v", registry, err)
+	}
+	if user == "" && secret == "" {
+		return authn.Anonymous, nil
+	}
+	// ECR and ACR's credential helpers return an identity token (for
+	// bearer auth) rather than a username/password pair when they have
+	// no real username to report.
+	if user == "" {
+		return authn.FromConfig(authn.AuthConfig{IdentityToken: secret}), nil
+	}
+	return authn.FromConfig(authn.AuthConfig{Username: user, Password: secret}), nil
+}
+
+// gcrKeychain authenticates against gcr.io, *.gcr.io and
+// *-docker.pkg.dev using whatever Google credentials are ambient
+// (gcloud config, a service account key, or GCE/GKE metadata) - the same
+// google.DefaultClient machinery StackdriverSource relies on.
+type gcrKeychainT struct{}
+
+func (gcrKeychainT) Resolve(registry string) (Authenticator, error) {
+	if registry != "gcr.io" && !strings.HasSuffix(registry, ".gcr.io") && !strings.HasSuffix(registry, "-docker.pkg.dev") {
+		return authn.Anonymous, nil
+	}
+	return google.Keychain.Resolve(dummyResource{registry})
+}
+
+// dummyResource adapts a bare registry hostname to authn.Resource so it
+// can be handed to authn.Keychain implementations, none of which look
+// past RegistryStr().
+type dummyResource struct{ registry string }
+
+func (d dummyResource) String() string      { return d.registry }
+func (d dummyResource) RegistryStr() string { return d.registry }
+
+var gcrKeychain = gcrKeychainT{}
+
+// ecrKeychain authenticates against *.dkr.ecr.*.amazonaws.com using the
+// AWS SDK's default credential chain.
+var ecrKeychain = &helperKeychain{
+	matches: func(registry string) bool {
+		return strings.Contains(registry, ".dkr.ecr.") && strings.HasSuffix(registry, ".amazonaws.com")
+	},
+	helper: ecrlogin.NewECRHelper(),
+}
+
+// acrKeychain authenticates against *.azurecr.io using the Azure SDK's
+// default credential chain.
+var acrKeychain = &helperKeychain{
+	matches: func(registry string) bool {
+		return strings.HasSuffix(registry, ".azurecr.io")
+	},
+	helper: credhelper.NewACRCredentialsHelper(),
+}
+
+// dockerConfigKeychain resolves credentials from a parsed Docker
+// config.json (the "auths" section, including the credsStore/credHelpers
+// forms the docker config package already knows how to read). explicit
+// distinguishes a user-supplied --auth-file (where a missing file is an
+// error) from the default ~/.docker/config.json (where it's fine for
+// nothing to be there).
+type dockerConfigKeychain struct {
+	path     string
+	explicit bool
+}
+
+func (k *dockerConfigKeychain) Resolve(registry string) (Authenticator, error) {
+	f, err := os.Open(k.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if k.explicit {
+				return nil, fmt.Errorf("--auth-file %q does not exist", k.path)
+			}
+			return authn.Anonymous, nil
+		}
+		return nil, fmt.Errorf("failed to open docker config %q: %v", k.path, err)
+	}
+	defer f.Close()
+
+	cf, err := config.LoadFromReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse docker config %q: %v", k.path, err)
+	}
+	authConfig, err := cf.GetAuthConfig(registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up credentials for %q in %q: %v", registry, k.path, err)
+	}
+	if authConfig.Username == "" && authConfig.Password == "" && authConfig.IdentityToken == "" {
+		return authn.Anonymous, nil
+	}
+	return authn.FromConfig(authn.AuthConfig{
+		Username:      authConfig.Username,
+		Password:      authConfig.Password,
+		Auth:          authConfig.Auth,
+		IdentityToken: authConfig.IdentityToken,
+		RegistryToken: authConfig.RegistryToken,
+	}), nil
+}
+
+// multiKeychain tries each Keychain in order and returns the first
+// credential that isn't anonymous, falling back to anonymous pulls if
+// none of them recognize the registry.
+type multiKeychain struct {
+	keychains []Keychain
+}
+
+func (m *multiKeychain) Resolve(registry string) (Authenticator, error) {
+	for _, k := range m.keychains {
+		auth, err := k.Resolve(registry)
+		if err != nil {
+			return nil, err
+		}
+		if auth != authn.Anonymous {
+			return auth, nil
+		}
+	}
+	return authn.Anonymous, nil
+}
+
+// ResolveKeychain builds the Keychain used to authenticate pulls,
+// consulting sources in the order an operator would expect to override
+// them: an explicit --auth-file first, then the default
+// ~/.docker/config.json, and finally the GCR/ECR/ACR cloud keychains as
+// a fallback for registries with no matching config.json entry.
+func ResolveKeychain(authFile string) (Keychain, error) {
+	var keychains []Keychain
+	if authFile != "" {
+		keychains = append(keychains, &dockerConfigKeychain{path: authFile, explicit: true})
+	}
+	home, err := os.UserHomeDir()
+	if err != nil && authFile == "" {
+		return nil, fmt.Errorf("failed to determine home directory: %v", err)
+	}
+	if err == nil {
+		keychains = append(keychains, &dockerConfigKeychain{path: filepath.Join(home, ".docker", "config.json")})
+	}
+	keychains = append(keychains, gcrKeychain, ecrKeychain, acrKeychain)
+	return &multiKeychain{keychains: keychains}, nil
+}
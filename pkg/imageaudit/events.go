@@ -0,0 +1,69 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EventSource is a LogSource that lists Pulling/Pulled events straight
+// from a cluster's Kubernetes API, instead of going through Stackdriver.
+// It's the right choice when the caller already has API access to the
+// cluster it wants to audit.
+type EventSource struct {
+	client kubernetes.Interface
+}
+
+// NewEventSource returns an EventSource that queries client. The
+// cluster argument to ListPulledImages is ignored since client is
+// already scoped to a single cluster.
+func NewEventSource(client kubernetes.Interface) *EventSource {
+	return &EventSource{client: client}
+}
+
+// ListPulledImages lists v1.Event objects across all namespaces whose
+// reason is "Pulling" or "Pulled" and returns the distinct image
+// references extracted from their messages.
+func (e *EventSource) ListPulledImages(ctx context.Context, cluster string) ([]string, error) {
+	events, err := e.client.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %v", err)
+	}
+
+	images := map[string]struct{}{}
+	for i := range events.Items {
+		event := &events.Items[i]
+		if event.Reason != "Pulling" && event.Reason != "Pulled" {
+			continue
+		}
+		image, err := extractImageNameFromEvent(event)
+		if err != nil {
+			continue
+		}
+		images[image] = struct{}{}
+	}
+
+	var out []string
+	for image := range images {
+		out = append(out, image)
+	}
+	return out, nil
+}
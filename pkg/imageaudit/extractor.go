@@ -0,0 +1,272 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/glog"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Extractor pulls images from a registry and exports their filesystems
+// to disk, without ever creating a container or requiring a Docker
+// daemon.
+type Extractor struct {
+	Keychain Keychain
+	Platform *v1.Platform
+	Resolver *ReferenceResolver
+}
+
+// NewExtractor returns an Extractor that authenticates pulls with
+// keychain, resolves image strings with resolver, and, for manifest
+// lists / OCI indexes, selects platform.
+func NewExtractor(keychain Keychain, platform *v1.Platform, resolver *ReferenceResolver) *Extractor {
+	return &Extractor{Keychain: keychain, Platform: platform, Resolver: resolver}
+}
+
+// Pull resolves imageRef against a registry and returns the image,
+// selecting the entry for e.Platform when the reference serves a
+// manifest list or OCI image index rather than a single-platform
+// manifest, along with the fully
+// normalized reference imageRef resolved to (so callers don't have to
+// re-derive it for logging or manifest emission).
+func (e *Extractor) Pull(imageRef string) (v1.Image, string, error) {
+	if len(imageRef) == 0 {
+		return nil, "", fmt.Errorf("image name must be non-empty")
+	}
+	ref, err := e.Resolver.Resolve(imageRef)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse image reference %q: %v", imageRef, err)
+	}
+	resolvedRef := ref.String()
+	auth, err := e.Keychain.Resolve(ref.Context().RegistryStr())
+	if err != nil {
+		return nil, resolvedRef, fmt.Errorf("failed to resolve credentials for %q: %v", imageRef, err)
+	}
+	desc, err := remote.Get(ref, remote.WithAuth(auth))
+	if err != nil {
+		return nil, resolvedRef, fmt.Errorf("failed to pull image %q: %v", imageRef, err)
+	}
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return nil, resolvedRef, fmt.Errorf("failed to read image index for %q: %v", imageRef, err)
+		}
+		img, err := selectManifest(idx, e.Platform)
+		return img, resolvedRef, err
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return nil, resolvedRef, fmt.Errorf("failed to read image %q: %v", imageRef, err)
+	}
+	return img, resolvedRef, nil
+}
+
+// Export walks img's layers in order, applying each one on top of the
+// last so the final content of rootfsDir matches what a container
+// started from img would see.
+func (e *Extractor) Export(img v1.Image, rootfsDir string) error {
+	if err := ensureRootfsDir(rootfsDir); err != nil {
+		return fmt.Errorf("invalid rootfs directory %q: %v", rootfsDir, err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return fmt.Errorf("failed to get image layers: %v", err)
+	}
+	for i, layer := range layers {
+		glog.Infof("Applying layer %d/%d", i+1, len(layers))
+		if err := applyLayer(layer, rootfsDir); err != nil {
+			return fmt.Errorf("failed to apply layer %d/%d: %v", i+1, len(layers), err)
+		}
+	}
+	return nil
+}
+
+// PodManifest builds a Pod spec describing how to run img (as pulled
+// from imageRef) and writes it, in format ("yaml" or "json"), next to
+// rootfsDir.
+func (e *Extractor) PodManifest(imageRef string, img v1.Image, rootfsDir, format string) error {
+	pod, err := podFromImage(imageRef, img)
+	if err != nil {
+		return fmt.Errorf("failed to build a pod manifest for %q: %v", imageRef, err)
+	}
+	return writeManifest(pod, rootfsDir, format)
+}
+
+func ensureRootfsDir(path string) error {
+	fInfo, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Create a new directory if it does not exist.
+			return os.Mkdir(path, 0755)
+		}
+		return fmt.Errorf("unable to stat the given directory %q: %v", path, err)
+	} else if !fInfo.IsDir() {
+		return fmt.Errorf("path %q is not a directory", path)
+	}
+	return nil
+}
+
+// whiteoutPrefix and whiteoutOpaque mark a file as a "whiteout" in the
+// AUFS/overlay sense: the file it shadows should be removed when
+// applying this layer.
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// applyLayer extracts a single image layer's tar stream on top of dest,
+// honoring tar whiteouts so that layer deletions are reflected in the
+// final rootfs.
+func applyLayer(layer v1.Layer, dest string) error {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return fmt.Errorf("failed to read layer: %v", err)
+	}
+	defer rc.Close()
+	return untar(rc, dest)
+}
+
+// untar streams a layer's tar content onto dest, applying whiteout
+// semantics as it goes:
+//   - a ".wh..wh..opq" entry opaques (clears) the directory it lives in
+//   - a ".wh.<name>" entry removes "<name>" from its directory
+//   - everything else is extracted normally, including hardlinks and
+//     symlinks
+//
+// Every entry name is resolved relative to dest and rejected if it
+// would land outside dest, since layer content is attacker-controlled
+// once images can be pulled from arbitrary registries.
+func untar(reader io.Reader, dest string) error {
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		name := filepath.Clean(header.Name)
+		dir, base := filepath.Split(name)
+
+		if base == whiteoutOpaque {
+			opaqueTarget, err := safeJoin(dest, dir)
+			if err != nil {
+				return err
+			}
+			if err := opaqueDir(opaqueTarget); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			target, err := safeJoin(dest, filepath.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+			if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(target); err != nil {
+				return fmt.Errorf("failed to apply whiteout for %q: %v", target, err)
+			}
+			continue
+		}
+
+		path, err := safeJoin(dest, name)
+		if err != nil {
+			return err
+		}
+		if err := extractEntry(tarReader, header, dest, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// safeJoin joins dest with a tar entry's (already cleaned) name and
+// rejects the result if it would resolve outside dest - a zero-trust
+// guard against path traversal ("zip slip") in layer content, since a
+// crafted entry name like "../../etc/passwd" would otherwise let a
+// malicious image overwrite arbitrary files on the host doing the
+// export.
+func safeJoin(dest, name string) (string, error) {
+	path := filepath.Join(dest, name)
+	cleanDest := filepath.Clean(dest)
+	if path != cleanDest && !strings.HasPrefix(path, cleanDest+string(os.PathSeparator)) {
+		return "", fmt.Errorf("tar entry %q escapes extraction root %q", name, dest)
+	}
+	return path, nil
+}
+
+// opaqueDir clears the contents of dir, implementing the semantics of a
+// ".wh..wh..opq" entry: everything the base image put in dir is hidden
+// by this layer.
+func opaqueDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to opaque directory %q: %v", dir, err)
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to opaque directory %q: %v", dir, err)
+		}
+	}
+	return nil
+}
+
+// extractEntry writes a single tar entry to path, dispatching on the
+// entry type so directories, regular files, symlinks and hardlinks are
+// all reproduced faithfully in the rootfs. dest is the extraction root
+// that hardlink targets - which tar stores relative to the layer root,
+// not to the linking file's own directory - are resolved against.
+func extractEntry(tarReader *tar.Reader, header *tar.Header, dest, path string) error {
+	info := header.FileInfo()
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(path, info.Mode())
+	case tar.TypeSymlink:
+		os.Remove(path)
+		return os.Symlink(header.Linkname, path)
+	case tar.TypeLink:
+		os.Remove(path)
+		linkPath, err := safeJoin(dest, filepath.Clean(strings.TrimPrefix(header.Linkname, "/")))
+		if err != nil {
+			return err
+		}
+		return os.Link(linkPath, path)
+	default:
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(file, tarReader)
+		return err
+	}
+}
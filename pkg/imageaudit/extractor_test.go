@@ -0,0 +1,85 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTarEntry(t *testing.T, tw *tar.Writer, header *tar.Header, content string) {
+	t.Helper()
+	header.Size = int64(len(content))
+	if err := tw.WriteHeader(header); err != nil {
+		t.Fatalf("failed to write tar header for %q: %v", header.Name, err)
+	}
+	if content != "" {
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar content for %q: %v", header.Name, err)
+		}
+	}
+}
+
+func TestUntarHardlinkAcrossDirectories(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	writeTarEntry(t, tw, &tar.Header{Name: "dir1/", Typeflag: tar.TypeDir, Mode: 0755}, "")
+	writeTarEntry(t, tw, &tar.Header{Name: "dir1/file.txt", Typeflag: tar.TypeReg, Mode: 0644}, "hello")
+	writeTarEntry(t, tw, &tar.Header{Name: "dir2/", Typeflag: tar.TypeDir, Mode: 0755}, "")
+	writeTarEntry(t, tw, &tar.Header{Name: "dir2/hardlink.txt", Typeflag: tar.TypeLink, Linkname: "dir1/file.txt"}, "")
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := untar(&buf, dest); err != nil {
+		t.Fatalf("untar failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dest, "dir2", "hardlink.txt"))
+	if err != nil {
+		t.Fatalf("failed to read hardlinked file: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("got %q, want %q", got, "hello")
+	}
+}
+
+func TestUntarRejectsPathTraversal(t *testing.T) {
+	tests := []string{
+		"../../etc/passwd",
+		"dir/../../../etc/passwd",
+	}
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			writeTarEntry(t, tw, &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644}, "pwned")
+			if err := tw.Close(); err != nil {
+				t.Fatalf("failed to close tar writer: %v", err)
+			}
+
+			dest := t.TempDir()
+			if err := untar(&buf, dest); err == nil {
+				t.Fatalf("expected untar to reject entry %q as escaping %q", name, dest)
+			}
+		})
+	}
+}
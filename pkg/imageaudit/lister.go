@@ -0,0 +1,47 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package imageaudit lists the images a Kubernetes cluster has pulled
+// and exports those images' filesystems without needing a Docker daemon.
+package imageaudit
+
+import "context"
+
+// LogSource discovers the set of images that were pulled onto a
+// cluster. Implementations differ in where they look: Stackdriver logs
+// for clusters that ship logs off-box, or the Kubernetes events API for
+// clusters queried directly.
+type LogSource interface {
+	// ListPulledImages returns the distinct set of image references
+	// observed being pulled on cluster.
+	ListPulledImages(ctx context.Context, cluster string) ([]string, error)
+}
+
+// Lister finds the images that have been pulled onto a cluster by
+// delegating to a LogSource.
+type Lister struct {
+	source LogSource
+}
+
+// NewLister returns a Lister backed by source.
+func NewLister(source LogSource) *Lister {
+	return &Lister{source: source}
+}
+
+// List returns the distinct set of image references pulled onto cluster.
+func (l *Lister) List(ctx context.Context, cluster string) ([]string, error) {
+	return l.source.ListPulledImages(ctx, cluster)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	kubeapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// podFromImage translates an image's runtime config (entrypoint, cmd,
+// env, exposed ports, working dir, user) into a single-container Pod
+// spec describing how to run it, in the spirit of the podex bootstrap
+// tool.
+func podFromImage(imageRef string, img v1.Image) (*kubeapi.Pod, error) {
+	cfgFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image config: %v", err)
+	}
+	cfg := cfgFile.Config
+	name := containerName(imageRef)
+
+	container := kubeapi.Container{
+		Name:       name,
+		Image:      imageRef,
+		Command:    cfg.Entrypoint,
+		Args:       cfg.Cmd,
+		WorkingDir: cfg.WorkingDir,
+		Env:        envVars(cfg.Env),
+		Ports:      containerPorts(cfg.ExposedPorts),
+	}
+	if uid, ok := parseNumericUser(cfg.User); ok {
+		container.SecurityContext = &kubeapi.SecurityContext{RunAsUser: &uid}
+	}
+
+	return &kubeapi.Pod{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: kubeapi.PodSpec{
+			Containers:    []kubeapi.Container{container},
+			RestartPolicy: kubeapi.RestartPolicyNever,
+		},
+	}, nil
+}
+
+// containerName derives a DNS-1123-ish name from an image reference,
+// e.g. "gcr.io/foo/bar:v1" becomes "bar".
+func containerName(imageRef string) string {
+	ref := imageRef
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		ref = ref[i+1:]
+	}
+	if i := strings.IndexAny(ref, ":@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if ref == "" {
+		return "container"
+	}
+	return ref
+}
+
+// envVars splits "KEY=VALUE" image config entries into EnvVar pairs.
+func envVars(env []string) []kubeapi.EnvVar {
+	var out []kubeapi.EnvVar
+	for _, e := range env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		out = append(out, kubeapi.EnvVar{Name: parts[0], Value: parts[1]})
+	}
+	return out
+}
+
+// containerPorts translates the image config's ExposedPorts
+// (e.g. "8080/tcp") into ContainerPort entries.
+func containerPorts(exposed map[string]struct{}) []kubeapi.ContainerPort {
+	var out []kubeapi.ContainerPort
+	for portProto := range exposed {
+		parts := strings.SplitN(portProto, "/", 2)
+		port, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		proto := kubeapi.ProtocolTCP
+		if len(parts) == 2 && strings.EqualFold(parts[1], "udp") {
+			proto = kubeapi.ProtocolUDP
+		}
+		out = append(out, kubeapi.ContainerPort{ContainerPort: int32(port), Protocol: proto})
+	}
+	return out
+}
+
+// parseNumericUser returns the numeric UID in user, which may be a bare
+// UID or a "uid:gid" pair (the group is ignored). It reports false for
+// an empty user or a named user we have no way to resolve without the
+// image's /etc/passwd.
+func parseNumericUser(user string) (int64, bool) {
+	if user == "" {
+		return 0, false
+	}
+	if i := strings.IndexByte(user, ':'); i >= 0 {
+		user = user[:i]
+	}
+	uid, err := strconv.ParseInt(user, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uid, true
+}
+
+// writeManifest renders pod as YAML or JSON and writes it next to
+// rootfsDir, named "<rootfsDir>.pod.<format>".
+func writeManifest(pod *kubeapi.Pod, rootfsDir, format string) error {
+	var data []byte
+	var err error
+	switch format {
+	case "yaml":
+		data, err = yaml.Marshal(pod)
+	case "json":
+		data, err = json.MarshalIndent(pod, "", "  ")
+	default:
+		return fmt.Errorf("invalid manifest format %q; expect %q or %q", format, "yaml", "json")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal pod manifest: %v", err)
+	}
+
+	path := ManifestPath(rootfsDir, format)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write pod manifest %q: %v", path, err)
+	}
+	return nil
+}
+
+// ManifestPath returns the path writeManifest will use for rootfsDir and
+// format, so callers can report it without duplicating the convention.
+func ManifestPath(rootfsDir, format string) string {
+	return strings.TrimSuffix(rootfsDir, string(filepath.Separator)) + ".pod." + format
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containerd/containerd/platforms"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// ParsePlatform turns a "os/arch[/variant]" string, as accepted by
+// --platform, into a v1.Platform. An empty string resolves to the
+// platform this binary is running on.
+func ParsePlatform(platformStr string) (*v1.Platform, error) {
+	if len(platformStr) == 0 {
+		spec := platforms.DefaultSpec()
+		return &v1.Platform{OS: spec.OS, Architecture: spec.Architecture, Variant: spec.Variant}, nil
+	}
+	parts := strings.Split(platformStr, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid platform %q; expect os/arch[/variant]", platformStr)
+	}
+	plat := &v1.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		plat.Variant = parts[2]
+	}
+	return plat, nil
+}
+
+// platformMatches reports whether an index entry's platform satisfies
+// the requested one. The variant is only compared when the caller asked
+// for one, since most manifest lists leave it unset for non-ARM arches.
+func platformMatches(want *v1.Platform, have *v1.Platform) bool {
+	if have == nil {
+		return false
+	}
+	if want.OS != have.OS || want.Architecture != have.Architecture {
+		return false
+	}
+	if want.Variant != "" && want.Variant != have.Variant {
+		return false
+	}
+	return true
+}
+
+// selectManifest walks an image index looking for the manifest matching
+// platform, returning a clear, actionable error listing what is actually
+// available when nothing matches.
+func selectManifest(idx v1.ImageIndex, platform *v1.Platform) (v1.Image, error) {
+	indexManifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index manifest: %v", err)
+	}
+	var available []string
+	for _, m := range indexManifest.Manifests {
+		if platformMatches(platform, m.Platform) {
+			return idx.Image(m.Digest)
+		}
+		if m.Platform != nil {
+			available = append(available, m.Platform.String())
+		}
+	}
+	return nil, fmt.Errorf("no manifest matching platform %q found in index; available platforms: %s",
+		platform.String(), strings.Join(available, ", "))
+}
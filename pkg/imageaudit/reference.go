@@ -0,0 +1,155 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// ShortNameMode controls how a reference with no explicit registry
+// component (e.g. "nginx" rather than "docker.io/library/nginx") is
+// resolved.
+type ShortNameMode string
+
+const (
+	// ShortNameModePermissive expands a short name against the first
+	// configured search registry, defaulting the tag to "latest".
+	ShortNameModePermissive ShortNameMode = "permissive"
+	// ShortNameModeEnforcing rejects any reference that isn't already
+	// fully qualified.
+	ShortNameModeEnforcing ShortNameMode = "enforcing"
+	// ShortNameModeDisabled also rejects short names, but is meant to
+	// communicate "this policy is turned off", matching the
+	// containers/image naming convention.
+	ShortNameModeDisabled ShortNameMode = "disabled"
+)
+
+// ReferenceResolver normalizes user-supplied image strings into fully
+// qualified name.Reference values, the way github.com/distribution's
+// ParseNormalizedNamed does for domain/path/tag/digest, plus a
+// configurable policy for references that omit a registry.
+type ReferenceResolver struct {
+	Mode ShortNameMode
+	// SearchRegistries lists registries to try, in priority order, when
+	// expanding a short name in permissive mode. Populated from
+	// --registries-conf, or ["docker.io"] when that flag is unset.
+	SearchRegistries []string
+}
+
+// NewReferenceResolver returns a ReferenceResolver for mode, loading its
+// search registries from registriesConf (a registries.conf-style file
+// with an `unqualified-search-registries` array) when registriesConf is
+// non-empty.
+func NewReferenceResolver(mode ShortNameMode, registriesConf string) (*ReferenceResolver, error) {
+	switch mode {
+	case ShortNameModePermissive, ShortNameModeEnforcing, ShortNameModeDisabled:
+	default:
+		return nil, fmt.Errorf("invalid short-name mode %q; expect %q, %q or %q",
+			mode, ShortNameModePermissive, ShortNameModeEnforcing, ShortNameModeDisabled)
+	}
+
+	registries := []string{"docker.io"}
+	if registriesConf != "" {
+		parsed, err := parseSearchRegistries(registriesConf)
+		if err != nil {
+			return nil, err
+		}
+		registries = parsed
+	}
+	return &ReferenceResolver{Mode: mode, SearchRegistries: registries}, nil
+}
+
+var searchRegistriesRe = regexp.MustCompile(`(?s)unqualified-search-registries\s*=\s*\[(.*?)\]`)
+
+// parseSearchRegistries extracts the unqualified-search-registries list
+// from a registries.conf file, e.g.:
+//
+//	unqualified-search-registries = ["docker.io", "my-mirror.example.com"]
+func parseSearchRegistries(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registries conf %q: %v", path, err)
+	}
+	m := searchRegistriesRe.FindStringSubmatch(string(data))
+	if m == nil {
+		return nil, fmt.Errorf("no unqualified-search-registries entry found in %q", path)
+	}
+
+	var registries []string
+	for _, entry := range strings.Split(m[1], ",") {
+		entry = strings.Trim(strings.TrimSpace(entry), `"'`)
+		if entry != "" {
+			registries = append(registries, entry)
+		}
+	}
+	if len(registries) == 0 {
+		return nil, fmt.Errorf("unqualified-search-registries in %q is empty", path)
+	}
+	return registries, nil
+}
+
+// hasExplicitDomain reports whether ref names a registry explicitly, as
+// opposed to a short name like "nginx" or "myorg/nginx" that has to be
+// resolved against a search list. This mirrors the heuristic
+// distribution/reference uses to decide when to prepend docker.io.
+func hasExplicitDomain(ref string) bool {
+	repo := ref
+	if i := strings.Index(repo, "@"); i >= 0 {
+		repo = repo[:i]
+	}
+	first, _, found := strings.Cut(repo, "/")
+	if !found {
+		return false
+	}
+	return strings.ContainsAny(first, ".:") || first == "localhost"
+}
+
+// Resolve normalizes imageRef - handling domain/path/tag/digest the way
+// reference.ParseNormalizedNamed does, including registry-with-port refs
+// like "localhost:5000/foo:tag" and digest refs like "foo@sha256:..." -
+// applying the configured short-name policy when imageRef has no
+// explicit registry component.
+func (r *ReferenceResolver) Resolve(imageRef string) (name.Reference, error) {
+	if hasExplicitDomain(imageRef) {
+		return normalize(imageRef)
+	}
+
+	switch r.Mode {
+	case ShortNameModeEnforcing, ShortNameModeDisabled:
+		return nil, fmt.Errorf("%q is a short name; --short-name-mode=%s requires a fully-qualified reference", imageRef, r.Mode)
+	}
+
+	// Permissive: expand against the highest-priority search registry.
+	// A real multi-registry probe would HEAD each candidate in turn; we
+	// keep this deterministic, matching plain docker.io short-name
+	// expansion when no mirror is configured.
+	return normalize(r.SearchRegistries[0] + "/" + imageRef)
+}
+
+func normalize(imageRef string) (name.Reference, error) {
+	named, err := reference.ParseNormalizedNamed(imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference %q: %v", imageRef, err)
+	}
+	return name.ParseReference(reference.TagNameOnly(named).String())
+}
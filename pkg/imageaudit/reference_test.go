@@ -0,0 +1,99 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import "testing"
+
+func TestHasExplicitDomain(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want bool
+	}{
+		{"nginx", false},
+		{"nginx:latest", false},
+		{"myorg/nginx", false},
+		{"myorg/nginx:latest", false},
+		{"gcr.io/myorg/nginx", true},
+		{"gcr.io/myorg/nginx:latest", true},
+		{"docker.io/library/nginx", true},
+		{"localhost/nginx", true},
+		{"localhost:5000/nginx", true},
+		{"localhost:5000/nginx:latest", true},
+		{"myregistry.example.com:5000/foo", true},
+		// A bare "name:tag" with no slash at all has no repository path
+		// to hold a domain, regardless of the colon.
+		{"nginx:5000", false},
+		{"foo@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", false},
+		{"gcr.io/myorg/nginx@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", true},
+	}
+	for _, tt := range tests {
+		if got := hasExplicitDomain(tt.ref); got != tt.want {
+			t.Errorf("hasExplicitDomain(%q) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestReferenceResolverResolve(t *testing.T) {
+	t.Run("explicit domain resolves regardless of mode", func(t *testing.T) {
+		for _, mode := range []ShortNameMode{ShortNameModePermissive, ShortNameModeEnforcing, ShortNameModeDisabled} {
+			r := &ReferenceResolver{Mode: mode, SearchRegistries: []string{"docker.io"}}
+			ref, err := r.Resolve("gcr.io/myorg/nginx:v1")
+			if err != nil {
+				t.Fatalf("mode %s: unexpected error: %v", mode, err)
+			}
+			if got, want := ref.String(), "gcr.io/myorg/nginx:v1"; got != want {
+				t.Errorf("mode %s: got %q, want %q", mode, got, want)
+			}
+		}
+	})
+
+	t.Run("permissive expands short name against search registry", func(t *testing.T) {
+		r := &ReferenceResolver{Mode: ShortNameModePermissive, SearchRegistries: []string{"my-mirror.example.com"}}
+		ref, err := r.Resolve("nginx")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, want := ref.String(), "my-mirror.example.com/nginx:latest"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("enforcing rejects short name", func(t *testing.T) {
+		r := &ReferenceResolver{Mode: ShortNameModeEnforcing, SearchRegistries: []string{"docker.io"}}
+		if _, err := r.Resolve("nginx"); err == nil {
+			t.Fatal("expected an error for a short name in enforcing mode")
+		}
+	})
+
+	t.Run("disabled rejects short name", func(t *testing.T) {
+		r := &ReferenceResolver{Mode: ShortNameModeDisabled, SearchRegistries: []string{"docker.io"}}
+		if _, err := r.Resolve("nginx"); err == nil {
+			t.Fatal("expected an error for a short name in disabled mode")
+		}
+	})
+
+	t.Run("port in explicit domain isn't mistaken for a tag", func(t *testing.T) {
+		r := &ReferenceResolver{Mode: ShortNameModeEnforcing, SearchRegistries: []string{"docker.io"}}
+		ref, err := r.Resolve("localhost:5000/foo")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, want := ref.String(), "localhost:5000/foo:latest"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
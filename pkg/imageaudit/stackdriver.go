@@ -0,0 +1,129 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+	sd "google.golang.org/api/logging/v2beta1"
+
+	kubeapi "k8s.io/api/core/v1"
+)
+
+var (
+	// Example of an image pulling message:
+	//   pulling image "gcr.io/google_containers/echoserver:1.6"
+	imagePullingMsgRegex = regexp.MustCompile(`pulling image "([a-z0-9.\-:_/]+)"`)
+	// Container image "gcr.io/google-containers/nginx-slim-amd64:0.20" already
+	// present on machine
+	imagePulledMsgRegex1 = regexp.MustCompile(`Container image "([a-z0-9.\-:_/]+)"`)
+	// Successfully pulled image
+	// "gcr.io/google_containers/cluster-proportional-autoscaler-amd64:1.1.2-r2"
+	imagePulledMsgRegex2 = regexp.MustCompile(`Successfully pulled image "([a-z0-9.\-:_/]+)"`)
+)
+
+// StackdriverSource is a LogSource that reads Pulled events for a GKE
+// cluster out of its Stackdriver logs.
+type StackdriverSource struct {
+	projectID string
+	svc       *sd.Service
+}
+
+// NewStackdriverSource returns a StackdriverSource for projectID,
+// authenticating with the ambient Google credentials (gcloud config, a
+// service account key, or GCE/GKE metadata).
+func NewStackdriverSource(ctx context.Context, projectID string) (*StackdriverSource, error) {
+	hc, err := google.DefaultClient(ctx, sd.CloudPlatformReadOnlyScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Stackdriver client: %v", err)
+	}
+	svc, err := sd.New(hc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stackdriver service: %v", err)
+	}
+	return &StackdriverSource{projectID: projectID, svc: svc}, nil
+}
+
+func buildFilter(projectID, clusterName, reason string) string {
+	conditions := []string{
+		`resource.type="gke_cluster"`,
+		fmt.Sprintf(`resource.labels.project_id ="%s"`, projectID),
+		fmt.Sprintf(`resource.labels.cluster_name="%s"`, clusterName),
+		fmt.Sprintf(`jsonPayload.reason="%s"`, reason),
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func extractImageNameFromEvent(event *kubeapi.Event) (string, error) {
+	for _, re := range []*regexp.Regexp{imagePullingMsgRegex, imagePulledMsgRegex1, imagePulledMsgRegex2} {
+		matches := re.FindStringSubmatch(event.Message)
+		if len(matches) == 2 {
+			return matches[1], nil
+		} else if len(matches) > 2 {
+			return "", fmt.Errorf("found more than one match when extracting the image name: %+v", matches)
+		}
+	}
+	return "", fmt.Errorf("could not extract image name from %q", event.Message)
+}
+
+// ListPulledImages queries Stackdriver for the "Pulled" events logged
+// against cluster and returns the distinct image references found.
+func (s *StackdriverSource) ListPulledImages(ctx context.Context, cluster string) ([]string, error) {
+	filter := buildFilter(s.projectID, cluster, "Pulled")
+	req := &sd.ListLogEntriesRequest{
+		ResourceNames: []string{fmt.Sprintf("projects/%s", s.projectID)},
+		Filter:        filter,
+	}
+
+	images := map[string]struct{}{}
+	pageToken := ""
+	for {
+		req.PageToken = pageToken
+		res, err := s.svc.Entries.List(req).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the log entries: %v", err)
+		}
+
+		for _, entry := range res.Entries {
+			var event kubeapi.Event
+			if err := json.Unmarshal(entry.JsonPayload, &event); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal into an Event: %v", err)
+			}
+			image, err := extractImageNameFromEvent(&event)
+			if err != nil {
+				continue
+			}
+			images[image] = struct{}{}
+		}
+
+		pageToken = res.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	var out []string
+	for image := range images {
+		out = append(out, image)
+	}
+	return out, nil
+}
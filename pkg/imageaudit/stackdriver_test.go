@@ -0,0 +1,78 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageaudit
+
+import (
+	"testing"
+
+	kubeapi "k8s.io/api/core/v1"
+)
+
+func TestExtractImageNameFromEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "pulling",
+			message: `pulling image "gcr.io/google_containers/echoserver:1.6"`,
+			want:    "gcr.io/google_containers/echoserver:1.6",
+		},
+		{
+			name:    "already present",
+			message: `Container image "gcr.io/google-containers/nginx-slim-amd64:0.20" already present on machine`,
+			want:    "gcr.io/google-containers/nginx-slim-amd64:0.20",
+		},
+		{
+			name:    "successfully pulled",
+			message: `Successfully pulled image "gcr.io/google_containers/cluster-proportional-autoscaler-amd64:1.1.2-r2"`,
+			want:    "gcr.io/google_containers/cluster-proportional-autoscaler-amd64:1.1.2-r2",
+		},
+		{
+			name:    "no match",
+			message: "Created container",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractImageNameFromEvent(&kubeapi.Event{Message: tt.message})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error for message %q", tt.message)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFilter(t *testing.T) {
+	got := buildFilter("my-project", "my-cluster", "Pulled")
+	want := `resource.type="gke_cluster" AND resource.labels.project_id ="my-project" AND resource.labels.cluster_name="my-cluster" AND jsonPayload.reason="Pulled"`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}